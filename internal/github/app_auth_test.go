@@ -0,0 +1,115 @@
+package github
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/go-github/v38/github"
+)
+
+func generateTestKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate test RSA key: %v", err)
+	}
+	return key
+}
+
+func TestSignAppJWT(t *testing.T) {
+	key := generateTestKey(t)
+	transport := &appInstallationTransport{appID: 123, privateKey: key}
+
+	signed, err := transport.signAppJWT()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	claims := &jwt.RegisteredClaims{}
+	_, err = jwt.ParseWithClaims(signed, claims, func(token *jwt.Token) (interface{}, error) {
+		return &key.PublicKey, nil
+	})
+	if err != nil {
+		t.Fatalf("failed to parse signed JWT: %v", err)
+	}
+
+	if claims.Issuer != "123" {
+		t.Errorf("expected issuer %q, got %q", "123", claims.Issuer)
+	}
+
+	lifetime := claims.ExpiresAt.Time.Sub(claims.IssuedAt.Time)
+	if lifetime <= 0 || lifetime > appJWTExpiry+time.Minute {
+		t.Errorf("expected a JWT lifetime around %s, got %s", appJWTExpiry, lifetime)
+	}
+}
+
+func TestInstallationTokenCachesAndRefreshes(t *testing.T) {
+	key := generateTestKey(t)
+
+	var mintCalls int
+	var expiresAt time.Time
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/app/installations/42/access_tokens", func(w http.ResponseWriter, r *http.Request) {
+		mintCalls++
+		expiresAt = time.Now().Add(2 * time.Minute)
+		fmt.Fprintf(w, `{"token":"installation-token-%d","expires_at":%q}`, mintCalls, expiresAt.Format(time.RFC3339))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	ghc := github.NewClient(nil)
+	baseURL, err := url.Parse(srv.URL + "/")
+	if err != nil {
+		t.Fatalf("failed to parse test server URL: %v", err)
+	}
+	ghc.BaseURL = baseURL
+
+	transport := &appInstallationTransport{
+		tokenClient:    newClient(ghc, WithRetryPolicy(zeroDelayPolicy())),
+		appID:          7,
+		installationID: 42,
+		privateKey:     key,
+	}
+
+	tok1, err := transport.installationToken(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if mintCalls != 1 {
+		t.Fatalf("expected 1 mint call, got %d", mintCalls)
+	}
+
+	tok2, err := transport.installationToken(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tok2 != tok1 {
+		t.Error("expected the cached token to be reused while it's still fresh")
+	}
+	if mintCalls != 1 {
+		t.Fatalf("expected no extra mint call for a still-fresh token, got %d calls", mintCalls)
+	}
+
+	// Force the cached token to look like it's within the refresh skew.
+	transport.expiry = time.Now().Add(tokenRefreshSkew / 2)
+
+	tok3, err := transport.installationToken(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tok3 == tok1 {
+		t.Error("expected a near-expiry token to be refreshed")
+	}
+	if mintCalls != 2 {
+		t.Fatalf("expected a second mint call after forcing expiry, got %d", mintCalls)
+	}
+}
@@ -0,0 +1,73 @@
+package github
+
+import (
+	"container/list"
+	"sync"
+)
+
+// etagCacheKey identifies one cached endpoint response. params is the
+// encoded query string (page, per_page, filter, ...) for the request, so
+// that two different pages of the same ref/endpoint - which are two
+// different resources as far as GitHub's ETags are concerned - don't
+// collide on one cache slot.
+type etagCacheKey struct {
+	owner, repo, ref, endpoint, params string
+}
+
+type etagCacheEntry struct {
+	key     etagCacheKey
+	etag    string
+	payload interface{}
+}
+
+// etagCache is a small in-memory LRU of the most recent ETag and payload
+// seen for each key, so a client can send If-None-Match and, on a 304,
+// return the cached payload instead of re-fetching it.
+type etagCache struct {
+	mu    sync.Mutex
+	size  int
+	ll    *list.List
+	items map[etagCacheKey]*list.Element
+}
+
+func newETagCache(size int) *etagCache {
+	return &etagCache{
+		size:  size,
+		ll:    list.New(),
+		items: make(map[etagCacheKey]*list.Element),
+	}
+}
+
+func (c *etagCache) get(key etagCacheKey) (etagCacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return etagCacheEntry{}, false
+	}
+	c.ll.MoveToFront(el)
+	return el.Value.(etagCacheEntry), true
+}
+
+func (c *etagCache) set(key etagCacheKey, etag string, payload interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		el.Value = etagCacheEntry{key: key, etag: etag, payload: payload}
+		c.ll.MoveToFront(el)
+		return
+	}
+
+	el := c.ll.PushFront(etagCacheEntry{key: key, etag: etag, payload: payload})
+	c.items[key] = el
+
+	if c.size > 0 && c.ll.Len() > c.size {
+		oldest := c.ll.Back()
+		if oldest != nil {
+			c.ll.Remove(oldest)
+			delete(c.items, oldest.Value.(etagCacheEntry).key)
+		}
+	}
+}
@@ -4,9 +4,16 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/go-github/v38/github"
+	"github.com/google/go-querystring/query"
 	"golang.org/x/oauth2"
 )
 
@@ -26,104 +33,601 @@ type (
 type Client interface {
 	GetCombinedStatus(ctx context.Context, owner, repo, ref string, opts *ListOptions) (*CombinedStatus, *Response, error)
 	ListCheckRunsForRef(ctx context.Context, owner, repo, ref string, opts *ListCheckRunsOptions) (*ListCheckRunsResults, *Response, error)
+
+	// ListAllCheckRunsForRef walks every page of check runs for ref and
+	// returns them combined, so callers never act on a partial set.
+	ListAllCheckRunsForRef(ctx context.Context, owner, repo, ref string, opts *ListCheckRunsOptions) ([]*CheckRun, error)
+	// GetFullCombinedStatus walks every page of the combined status for
+	// ref and returns it combined, so callers never act on a partial set.
+	GetFullCombinedStatus(ctx context.Context, owner, repo, ref string) (*CombinedStatus, error)
+}
+
+// RetryPolicy controls how a client retries a failed call. The zero value is
+// not directly usable; start from DefaultRetryPolicy and override the fields
+// that matter, e.g. a short, zero-jitter policy for tests.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of calls to make, including the
+	// first one.
+	MaxAttempts int
+	// InitialDelay seeds the backoff: the delay before the second attempt
+	// is computed from InitialDelay*Multiplier (see nextDelay), and
+	// InitialDelay is the floor of every delay.
+	InitialDelay time.Duration
+	// MaxDelay caps any single backoff, however the jitter computes it.
+	MaxDelay time.Duration
+	// Multiplier grows the backoff ceiling between attempts.
+	Multiplier float64
+	// JitterFraction blends between a deterministic capped-exponential
+	// delay (0) and a fully randomized decorrelated-jitter delay drawn
+	// from [InitialDelay, prev*Multiplier] (1). Set it to 0 for
+	// deterministic tests.
+	JitterFraction float64
+	// TotalTimeout bounds the wall-clock time spent retrying, on top of
+	// MaxAttempts. Zero means no overall cap.
+	TotalTimeout time.Duration
+	// Retryable decides whether a given response/error pair should be
+	// retried at all. Rate-limit responses are always handled separately
+	// regardless of what this returns.
+	Retryable func(*Response, error) bool
+}
+
+// DefaultRetryPolicy is used by NewClient and NewAppClient unless overridden
+// with WithRetryPolicy.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    5,
+		InitialDelay:   1 * time.Second,
+		MaxDelay:       30 * time.Second,
+		Multiplier:     2,
+		JitterFraction: 0.5,
+		Retryable:      defaultRetryable,
+	}
+}
+
+// defaultRetryable retries 5xx responses and errors that carry no response at
+// all (e.g. a transport-level failure from GitHub's load balancer). A plain
+// 4xx, including 403/404, is never retryable here - rate-limited 403/429 are
+// intercepted earlier by rateLimitWait.
+func defaultRetryable(resp *Response, err error) bool {
+	if err == nil {
+		return false
+	}
+	if resp == nil {
+		return true
+	}
+	return resp.StatusCode >= 500 && resp.StatusCode <= 599
 }
 
 type client struct {
 	ghc *github.Client
-	maxRetries int
-	retryDelay time.Duration
+
+	retryPolicy RetryPolicy
+
+	// maxRateLimitRetries bounds how many times a call will wait out a
+	// rate-limit response before giving up. It is tracked separately from
+	// retryPolicy.MaxAttempts so a flurry of 5xx errors can't starve a
+	// legitimate rate-limit wait, and vice versa.
+	maxRateLimitRetries int
+	// maxRateLimitWait caps how long we'll ever sleep for a single
+	// rate-limit retry, regardless of what Retry-After or
+	// X-RateLimit-Reset claims.
+	maxRateLimitWait time.Duration
+
+	// etagCache is nil unless WithETagCache was passed, in which case
+	// requests are conditional on the last ETag seen for that endpoint.
+	etagCache *etagCache
+}
+
+// Option configures a Client constructed by NewClient or NewAppClient.
+type Option func(*client)
+
+// WithRetryPolicy overrides the default retry policy used for 5xx and
+// transport-level errors.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *client) {
+		c.retryPolicy = policy
+	}
+}
+
+// WithMaxRateLimitRetries overrides how many times a call will wait out a
+// rate-limited response before giving up.
+func WithMaxRateLimitRetries(n int) Option {
+	return func(c *client) {
+		c.maxRateLimitRetries = n
+	}
 }
 
-func NewClient(ctx context.Context, token string) Client {
-	return &client{
-		ghc: github.NewClient(oauth2.NewClient(ctx, oauth2.StaticTokenSource(
-			&oauth2.Token{
-				AccessToken: token,
-			},
-		))),
-		maxRetries: 5,
-		retryDelay: 1 * time.Second,
+// WithMaxRateLimitWait caps how long a single rate-limit wait is allowed to
+// sleep, regardless of what GitHub's headers ask for.
+func WithMaxRateLimitWait(d time.Duration) Option {
+	return func(c *client) {
+		c.maxRateLimitWait = d
 	}
 }
 
+// WithETagCache makes the client send a conditional If-None-Match request
+// for every call, keeping an LRU of up to size (owner, repo, ref, endpoint,
+// query) entries. A 304 response returns the cached payload and, per GitHub's
+// documented behavior, doesn't count against the caller's rate limit.
+func WithETagCache(size int) Option {
+	return func(c *client) {
+		c.etagCache = newETagCache(size)
+	}
+}
+
+func newClient(ghc *github.Client, opts ...Option) *client {
+	c := &client{
+		ghc:                 ghc,
+		retryPolicy:         DefaultRetryPolicy(),
+		maxRateLimitRetries: 5,
+		maxRateLimitWait:    15 * time.Minute,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+func NewClient(ctx context.Context, token string, opts ...Option) Client {
+	ghc := github.NewClient(oauth2.NewClient(ctx, oauth2.StaticTokenSource(
+		&oauth2.Token{
+			AccessToken: token,
+		},
+	)))
+	return newClient(ghc, opts...)
+}
+
+const (
+	combinedStatusEndpoint = "combined-status"
+	listCheckRunsEndpoint  = "check-runs"
+)
+
 func (c *client) GetCombinedStatus(ctx context.Context, owner, repo, ref string, opts *ListOptions) (*CombinedStatus, *Response, error) {
+	params, err := encodeOptions(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	key := etagCacheKey{owner: owner, repo: repo, ref: ref, endpoint: combinedStatusEndpoint, params: params}
+	etag, cached := c.cachedETag(key)
+
 	var statusResp *CombinedStatus
-	var resp *Response
-	var err error
+	resp, err := c.invoke(ctx, "getting combined status", func() (*Response, error) {
+		u, err := addOptions(fmt.Sprintf("repos/%v/%v/commits/%v/status", owner, repo, refURLEscape(ref)), opts)
+		if err != nil {
+			return nil, err
+		}
 
-	for attempt := 0; attempt < c.maxRetries; attempt++ {
-		statusResp, resp, err = c.ghc.Repositories.GetCombinedStatus(ctx, owner, repo, ref, opts)
-		if err == nil {
-			return statusResp, resp, nil
+		req, err := c.ghc.NewRequest("GET", u, nil)
+		if err != nil {
+			return nil, err
 		}
+		setIfNoneMatch(req, etag)
 
-		// Check if context is canceled or deadline exceeded before retrying
-		if ctx.Err() != nil {
-			return nil, resp, fmt.Errorf("context error while getting combined status: %w", ctx.Err())
+		statusResp = new(CombinedStatus)
+		r, e := c.ghc.Do(ctx, req, statusResp)
+		if isNotModified(r) {
+			// Return a clone, never the literal cached pointer - a caller
+			// (e.g. GetFullCombinedStatus) may mutate what it gets back,
+			// and that must not corrupt the cache entry or alias with
+			// another concurrent caller holding the same cache hit.
+			if cs, ok := cached.(*CombinedStatus); ok {
+				statusResp = cloneCombinedStatus(cs)
+			}
+			return r, nil
 		}
-		
-		// Don't retry on deadline exceeded errors
-		if errors.Is(err, context.DeadlineExceeded) {
-			return nil, resp, err
+		return r, e
+	})
+	if err != nil {
+		return nil, resp, err
+	}
+
+	c.cacheETag(key, resp, statusResp)
+	return statusResp, resp, nil
+}
+
+func (c *client) ListCheckRunsForRef(ctx context.Context, owner, repo, ref string, opts *ListCheckRunsOptions) (*ListCheckRunsResults, *Response, error) {
+	params, err := encodeOptions(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	key := etagCacheKey{owner: owner, repo: repo, ref: ref, endpoint: listCheckRunsEndpoint, params: params}
+	etag, cached := c.cachedETag(key)
+
+	var checksResp *ListCheckRunsResults
+	resp, err := c.invoke(ctx, "listing check runs", func() (*Response, error) {
+		u, err := addOptions(fmt.Sprintf("repos/%v/%v/commits/%v/check-runs", owner, repo, refURLEscape(ref)), opts)
+		if err != nil {
+			return nil, err
 		}
 
-		// Only retry on 5xx server errors
-		if resp != nil && (resp.StatusCode < 500 || resp.StatusCode > 599) {
-			return statusResp, resp, err
+		req, err := c.ghc.NewRequest("GET", u, nil)
+		if err != nil {
+			return nil, err
 		}
+		setIfNoneMatch(req, etag)
 
-		// Wait with exponential backoff before retrying
-		if attempt < c.maxRetries-1 {
-			backoffDuration := c.retryDelay * time.Duration(1<<attempt)
-			select {
-			case <-ctx.Done():
-				return nil, resp, ctx.Err()
-			case <-time.After(backoffDuration):
-				// Continue with retry
+		checksResp = new(ListCheckRunsResults)
+		r, e := c.ghc.Do(ctx, req, checksResp)
+		if isNotModified(r) {
+			// See the equivalent comment in GetCombinedStatus: never hand
+			// back the literal cached pointer.
+			if results, ok := cached.(*ListCheckRunsResults); ok {
+				checksResp = cloneListCheckRunsResults(results)
 			}
+			return r, nil
 		}
+		return r, e
+	})
+	if err != nil {
+		return nil, resp, err
 	}
 
-	return nil, resp, fmt.Errorf("failed to get combined status after %d retries: %w", c.maxRetries, err)
+	c.cacheETag(key, resp, checksResp)
+	return checksResp, resp, nil
 }
 
-func (c *client) ListCheckRunsForRef(ctx context.Context, owner, repo, ref string, opts *ListCheckRunsOptions) (*ListCheckRunsResults, *Response, error) {
-	var checksResp *ListCheckRunsResults
+// cachedETag returns the ETag and payload last cached for key, if an ETag
+// cache is configured and holds an entry for it.
+func (c *client) cachedETag(key etagCacheKey) (etag string, payload interface{}) {
+	if c.etagCache == nil {
+		return "", nil
+	}
+	entry, ok := c.etagCache.get(key)
+	if !ok {
+		return "", nil
+	}
+	return entry.etag, entry.payload
+}
+
+// cacheETag records resp's ETag against key, if an ETag cache is configured
+// and resp actually carries one.
+func (c *client) cacheETag(key etagCacheKey, resp *Response, payload interface{}) {
+	if c.etagCache == nil || resp == nil {
+		return
+	}
+	if etag := resp.Header.Get("ETag"); etag != "" {
+		c.etagCache.set(key, etag, payload)
+	}
+}
+
+func setIfNoneMatch(req *http.Request, etag string) {
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+}
+
+// isNotModified reports whether resp is a 304 response to a conditional
+// request. go-github's CheckResponse treats any non-2xx status, including
+// 304, as an error, so the invoke retry loop would otherwise mistake a
+// cache hit for a broken response.
+func isNotModified(resp *Response) bool {
+	return resp != nil && resp.StatusCode == http.StatusNotModified
+}
+
+// refURLEscape percent-escapes ref for safe interpolation into a request
+// path, matching go-github's own internal refURLEscape. ref is escaped one
+// "/"-separated segment at a time rather than as a whole, because a ref such
+// as "heads/release/1.0" contains literal slashes that must survive as path
+// separators; escaping the full string would turn them into "%2F" and break
+// the route. Without this, a ref containing a reserved character (e.g. "#")
+// would otherwise be parsed by url.Parse as the start of a fragment,
+// silently truncating the path.
+func refURLEscape(ref string) string {
+	segments := strings.Split(ref, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return strings.Join(segments, "/")
+}
+
+// addOptions mirrors go-github's own (unexported) helper of the same name:
+// it encodes opts as a query string and appends it to path. opts may be nil
+// or a nil pointer, in which case path is returned unchanged.
+func addOptions(path string, opts interface{}) (string, error) {
+	q, err := encodeOptions(opts)
+	if err != nil {
+		return path, err
+	}
+	if q == "" {
+		return path, nil
+	}
+
+	u, err := url.Parse(path)
+	if err != nil {
+		return path, err
+	}
+	u.RawQuery = q
+	return u.String(), nil
+}
+
+// encodeOptions returns opts encoded as a query string, e.g. "page=2", or ""
+// for a nil opts (or nil pointer). It's also used to distinguish one page of
+// a paginated request from another in the ETag cache key - two pages of the
+// same ref/endpoint are different resources as far as GitHub's ETags go.
+func encodeOptions(opts interface{}) (string, error) {
+	v := reflect.ValueOf(opts)
+	if v.Kind() == reflect.Ptr && v.IsNil() {
+		return "", nil
+	}
+
+	qs, err := query.Values(opts)
+	if err != nil {
+		return "", err
+	}
+	return qs.Encode(), nil
+}
+
+// cloneCombinedStatus returns a shallow copy of s with its own Statuses
+// slice, so a caller appending to the returned value's Statuses can never
+// mutate the backing array of a cached CombinedStatus.
+func cloneCombinedStatus(s *CombinedStatus) *CombinedStatus {
+	if s == nil {
+		return nil
+	}
+	clone := *s
+	clone.Statuses = append([]*RepoStatus(nil), s.Statuses...)
+	return &clone
+}
+
+// cloneListCheckRunsResults returns a shallow copy of r with its own
+// CheckRuns slice, for the same reason as cloneCombinedStatus.
+func cloneListCheckRunsResults(r *ListCheckRunsResults) *ListCheckRunsResults {
+	if r == nil {
+		return nil
+	}
+	clone := *r
+	clone.CheckRuns = append([]*CheckRun(nil), r.CheckRuns...)
+	return &clone
+}
+
+// ListAllCheckRunsForRef walks resp.NextPage until exhausted, so a PR with
+// more than one page of check runs is evaluated completely instead of on
+// just its first per_page results. Each page fetch goes through
+// ListCheckRunsForRef, so it gets the same retry/backoff treatment as a
+// single-page call - a 5xx on page 3 of 5 doesn't lose pages 1-2.
+func (c *client) ListAllCheckRunsForRef(ctx context.Context, owner, repo, ref string, opts *ListCheckRunsOptions) ([]*CheckRun, error) {
+	pageOpts := ListCheckRunsOptions{}
+	if opts != nil {
+		pageOpts = *opts
+	}
+
+	var all []*CheckRun
+	for {
+		results, resp, err := c.ListCheckRunsForRef(ctx, owner, repo, ref, &pageOpts)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, results.CheckRuns...)
+
+		if resp == nil || resp.NextPage == 0 {
+			return all, nil
+		}
+		pageOpts.Page = resp.NextPage
+	}
+}
+
+// GetFullCombinedStatus walks resp.NextPage until exhausted, aggregating
+// Statuses and TotalCount while keeping the highest-severity State across
+// pages, so a PR with more than one page of statuses is evaluated
+// completely instead of on just its first per_page results. Each page fetch
+// goes through GetCombinedStatus, so it gets the same retry/backoff
+// treatment as a single-page call.
+func (c *client) GetFullCombinedStatus(ctx context.Context, owner, repo, ref string) (*CombinedStatus, error) {
+	opts := &ListOptions{}
+
+	var full *CombinedStatus
+	for {
+		status, resp, err := c.GetCombinedStatus(ctx, owner, repo, ref, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		if full == nil {
+			// Build our own aggregate object rather than adopting status
+			// as-is: status may be a pointer straight out of the ETag
+			// cache, and appending to it below would mutate that cached
+			// entry (and race with any other caller holding the same
+			// cache hit).
+			full = &CombinedStatus{
+				State:    status.State,
+				Statuses: append([]*RepoStatus(nil), status.Statuses...),
+			}
+		} else {
+			full.Statuses = append(full.Statuses, status.Statuses...)
+			full.State = highestSeverityState(full.State, status.State)
+		}
+		// Every page reports the same full total_count, not a per-page
+		// count (same convention as check-suites/workflow-runs/artifacts),
+		// so summing across pages would inflate it. Recompute it from
+		// what we've actually aggregated instead of trusting any one
+		// page's value.
+		full.TotalCount = intPtr(len(full.Statuses))
+
+		if resp == nil || resp.NextPage == 0 {
+			return full, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// combinedStatusSeverity ranks the possible CombinedStatus.State values from
+// least to most severe, matching GitHub's own precedence when it computes
+// the combined state from individual statuses.
+var combinedStatusSeverity = map[string]int{
+	"success": 0,
+	"pending": 1,
+	"error":   2,
+	"failure": 3,
+}
+
+// highestSeverityState returns whichever of a, b ranks higher in
+// combinedStatusSeverity, preferring a non-nil value over a nil one.
+func highestSeverityState(a, b *string) *string {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	if combinedStatusSeverity[*b] > combinedStatusSeverity[*a] {
+		return b
+	}
+	return a
+}
+
+// intPtr returns a pointer to v, for setting an optional *int field inline.
+func intPtr(v int) *int {
+	return &v
+}
+
+// invoke drives fn through the client's retry policy: 5xx/transport errors
+// are retried per policy with decorrelated-jitter backoff, rate-limited
+// responses are retried per the separate rate-limit budget, and everything
+// else is returned immediately. desc is used only to annotate error
+// messages, e.g. "getting combined status".
+func (c *client) invoke(ctx context.Context, desc string, fn func() (*Response, error)) (*Response, error) {
+	policy := c.retryPolicy
+	start := time.Now()
+	delay := policy.InitialDelay
+	rateLimitAttempts := 0
+
 	var resp *Response
 	var err error
 
-	for attempt := 0; attempt < c.maxRetries; attempt++ {
-		checksResp, resp, err = c.ghc.Checks.ListCheckRunsForRef(ctx, owner, repo, ref, opts)
+	for attempt := 0; attempt < policy.MaxAttempts; attempt++ {
+		if policy.TotalTimeout > 0 && time.Since(start) > policy.TotalTimeout {
+			return resp, fmt.Errorf("exceeded total retry timeout %s %s: %w", policy.TotalTimeout, desc, err)
+		}
+
+		resp, err = fn()
 		if err == nil {
-			return checksResp, resp, nil
+			return resp, nil
 		}
 
 		// Check if context is canceled or deadline exceeded before retrying
 		if ctx.Err() != nil {
-			return nil, resp, fmt.Errorf("context error while listing check runs: %w", ctx.Err())
+			return resp, fmt.Errorf("context error while %s: %w", desc, ctx.Err())
 		}
-		
+
 		// Don't retry on deadline exceeded errors
 		if errors.Is(err, context.DeadlineExceeded) {
-			return nil, resp, err
+			return resp, err
+		}
+
+		// A rate-limited response carries its own retry budget and wait
+		// time, independent of the policy's backoff below.
+		if wait, ok := rateLimitWait(err, resp); ok {
+			if rateLimitAttempts >= c.maxRateLimitRetries {
+				return resp, fmt.Errorf("exceeded %d rate-limit retries while %s: %w", c.maxRateLimitRetries, desc, err)
+			}
+			rateLimitAttempts++
+
+			if wait > c.maxRateLimitWait {
+				wait = c.maxRateLimitWait
+			}
+			select {
+			case <-ctx.Done():
+				return resp, ctx.Err()
+			case <-time.After(wait):
+			}
+
+			// This attempt didn't consume any of the policy's retry budget.
+			attempt--
+			continue
 		}
 
-		// Only retry on 5xx server errors
-		if resp != nil && (resp.StatusCode < 500 || resp.StatusCode > 599) {
-			return checksResp, resp, err
+		if !policy.Retryable(resp, err) {
+			return resp, err
 		}
 
-		// Wait with exponential backoff before retrying
-		if attempt < c.maxRetries-1 {
-			backoffDuration := c.retryDelay * time.Duration(1<<attempt)
+		if attempt < policy.MaxAttempts-1 {
+			wait := policy.nextDelay(delay)
 			select {
 			case <-ctx.Done():
-				return nil, resp, ctx.Err()
-			case <-time.After(backoffDuration):
-				// Continue with retry
+				return resp, ctx.Err()
+			case <-time.After(wait):
 			}
+			delay = wait
 		}
 	}
 
-	return nil, resp, fmt.Errorf("failed to list check runs after %d retries: %w", c.maxRetries, err)
+	return resp, fmt.Errorf("failed %s after %d attempts: %w", desc, policy.MaxAttempts, err)
+}
+
+// nextDelay computes the next backoff using decorrelated jitter: a value
+// drawn uniformly from [InitialDelay, prev*Multiplier], capped at MaxDelay.
+// JitterFraction blends between that random draw (1) and the plain
+// capped-exponential value (0).
+func (p RetryPolicy) nextDelay(prev time.Duration) time.Duration {
+	exp := time.Duration(float64(prev) * p.Multiplier)
+	if exp < p.InitialDelay {
+		exp = p.InitialDelay
+	}
+	if p.MaxDelay > 0 && exp > p.MaxDelay {
+		exp = p.MaxDelay
+	}
+
+	spread := exp - p.InitialDelay
+	if p.JitterFraction <= 0 || spread <= 0 {
+		return exp
+	}
+
+	jittered := p.InitialDelay + time.Duration(rand.Int63n(int64(spread)+1))
+	blended := time.Duration(float64(exp)*(1-p.JitterFraction) + float64(jittered)*p.JitterFraction)
+	if p.MaxDelay > 0 && blended > p.MaxDelay {
+		blended = p.MaxDelay
+	}
+	return blended
+}
+
+// rateLimitWait inspects a failed API call for GitHub's documented
+// rate-limit signals - a primary-limit 429, a secondary-limit/abuse-detection
+// 403, or plain Retry-After / X-RateLimit-Reset headers - and reports how
+// long to wait before retrying. It returns ok == false for any other error,
+// including a plain 403/404 that isn't rate-limit related.
+func rateLimitWait(err error, resp *Response) (time.Duration, bool) {
+	var rle *github.RateLimitError
+	if errors.As(err, &rle) {
+		return time.Until(rle.Rate.Reset.Time), true
+	}
+
+	var arle *github.AbuseRateLimitError
+	if errors.As(err, &arle) {
+		if arle.RetryAfter != nil {
+			return *arle.RetryAfter, true
+		}
+		return 0, true
+	}
+
+	if resp == nil || resp.Response == nil {
+		return 0, false
+	}
+
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusForbidden {
+		return 0, false
+	}
+
+	if ra := resp.Header.Get("Retry-After"); ra != "" {
+		if secs, parseErr := strconv.Atoi(ra); parseErr == nil {
+			return time.Duration(secs) * time.Second, true
+		}
+		if t, parseErr := http.ParseTime(ra); parseErr == nil {
+			return time.Until(t), true
+		}
+	}
+
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if reset := resp.Header.Get("X-RateLimit-Reset"); reset != "" {
+			if ts, parseErr := strconv.ParseInt(reset, 10, 64); parseErr == nil {
+				return time.Until(time.Unix(ts, 0)), true
+			}
+		}
+	}
+
+	// A 429 is a rate-limit response even when it carries neither header.
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return 0, true
+	}
+
+	return 0, false
 }
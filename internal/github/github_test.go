@@ -3,18 +3,28 @@ package github
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"testing"
+	"time"
 
 	"github.com/google/go-github/v38/github"
 )
 
 // MockClient implements the github.Client interface for tests
 type MockClient struct {
-	GetCombinedStatusCalls     int
-	ListCheckRunsForRefCalls   int
-	StatusCodes                []int
-	ShouldTimeout              bool
+	GetCombinedStatusCalls   int
+	ListCheckRunsForRefCalls int
+	StatusCodes              []int
+	ShouldTimeout            bool
+
+	// Pages, when non-empty, makes ListCheckRunsForRef serve one slice
+	// per call to opts.Page (1-based, same as the real API) instead of
+	// the single default page below - so tests can exercise multi-page
+	// assembly, including a failure injected via StatusCodes mid-pagination.
+	Pages [][]*CheckRun
 }
 
 func (m *MockClient) GetCombinedStatus(ctx context.Context, owner, repo, ref string, opts *ListOptions) (*CombinedStatus, *Response, error) {
@@ -71,12 +81,33 @@ func (m *MockClient) ListCheckRunsForRef(ctx context.Context, owner, repo, ref s
 			resp := &Response{
 				Response: &http.Response{
 					StatusCode: code,
+					Header:     http.Header{},
 				},
 			}
 			return nil, resp, errors.New("API error")
 		}
 	}
 
+	if len(m.Pages) > 0 {
+		page := 0
+		if opts != nil && opts.Page > 1 {
+			page = opts.Page - 1
+		}
+		if page >= len(m.Pages) {
+			page = len(m.Pages) - 1
+		}
+
+		resp := &Response{Response: &http.Response{StatusCode: 200}}
+		if page < len(m.Pages)-1 {
+			resp.NextPage = page + 2
+		}
+
+		return &ListCheckRunsResults{
+			Total:     github.Int(len(m.Pages[page])),
+			CheckRuns: m.Pages[page],
+		}, resp, nil
+	}
+
 	// Default success response
 	return &ListCheckRunsResults{
 		Total: github.Int(1),
@@ -94,7 +125,72 @@ func (m *MockClient) ListCheckRunsForRef(ctx context.Context, owner, repo, ref s
 	}, nil
 }
 
-func TestGetCombinedStatusRetry(t *testing.T) {
+func (m *MockClient) ListAllCheckRunsForRef(ctx context.Context, owner, repo, ref string, opts *ListCheckRunsOptions) ([]*CheckRun, error) {
+	pageOpts := ListCheckRunsOptions{}
+	if opts != nil {
+		pageOpts = *opts
+	}
+
+	var all []*CheckRun
+	for {
+		results, resp, err := m.ListCheckRunsForRef(ctx, owner, repo, ref, &pageOpts)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, results.CheckRuns...)
+
+		if resp == nil || resp.NextPage == 0 {
+			return all, nil
+		}
+		pageOpts.Page = resp.NextPage
+	}
+}
+
+func (m *MockClient) GetFullCombinedStatus(ctx context.Context, owner, repo, ref string) (*CombinedStatus, error) {
+	opts := &ListOptions{}
+
+	var full *CombinedStatus
+	for {
+		status, resp, err := m.GetCombinedStatus(ctx, owner, repo, ref, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		if full == nil {
+			full = &CombinedStatus{
+				State:    status.State,
+				Statuses: append([]*RepoStatus(nil), status.Statuses...),
+			}
+		} else {
+			full.Statuses = append(full.Statuses, status.Statuses...)
+			full.State = highestSeverityState(full.State, status.State)
+		}
+		// Mirrors the real client: every page reports the same full
+		// total_count, so it's recomputed from what's been aggregated
+		// rather than summed across pages.
+		full.TotalCount = intPtr(len(full.Statuses))
+
+		if resp == nil || resp.NextPage == 0 {
+			return full, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// zeroDelayPolicy lets tests exercise the real retry loop in client.invoke
+// without actually sleeping.
+func zeroDelayPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    5,
+		InitialDelay:   0,
+		MaxDelay:       0,
+		Multiplier:     2,
+		JitterFraction: 0,
+		Retryable:      defaultRetryable,
+	}
+}
+
+func TestInvokeRetry(t *testing.T) {
 	tests := map[string]struct {
 		statusCodes   []int
 		expectedCalls int
@@ -116,9 +212,9 @@ func TestGetCombinedStatusRetry(t *testing.T) {
 			expectedCalls: 3,
 			shouldSucceed: true,
 		},
-		"fail after max retries": {
+		"fail after max attempts": {
 			statusCodes:   []int{500, 500, 500, 500, 500, 500},
-			expectedCalls: 5, // maxRetries
+			expectedCalls: 5, // policy.MaxAttempts
 			shouldSucceed: false,
 		},
 		"don't retry on 4xx errors": {
@@ -126,170 +222,368 @@ func TestGetCombinedStatusRetry(t *testing.T) {
 			expectedCalls: 1,
 			shouldSucceed: false,
 		},
-		"don't retry on rate limits": {
+		"don't retry on a plain 403": {
 			statusCodes:   []int{403},
 			expectedCalls: 1,
 			shouldSucceed: false,
 		},
 		"timeout during retry": {
 			shouldTimeout: true,
-			expectedCalls: 1, 
+			expectedCalls: 1,
 			shouldSucceed: false,
 		},
 	}
 
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			mockClient := &MockClient{
-				StatusCodes:   tc.statusCodes,
-				ShouldTimeout: tc.shouldTimeout,
-			}
+			c := newClient(nil, WithRetryPolicy(zeroDelayPolicy()))
 
-			ctx := context.Background()
-			var result *CombinedStatus
-			var resp *Response
-			var err error
-			
-			// Use modified version of the retry code for testing
-			for attempt := 0; attempt < 5; attempt++ {
-				result, resp, err = mockClient.GetCombinedStatus(ctx, "owner", "repo", "ref", &ListOptions{})
-				if err == nil {
-					break
-				}
-				
-				// For timeout error, don't retry
-				if errors.Is(err, context.DeadlineExceeded) {
-					break
+			calls := 0
+			fn := func() (*Response, error) {
+				calls++
+				if tc.shouldTimeout {
+					return nil, context.DeadlineExceeded
 				}
-				
-				// Only retry on 5xx server errors
-				if resp != nil && (resp.StatusCode < 500 || resp.StatusCode > 599) {
-					break
-				}
-				
-				// Don't actually sleep in tests, just continue to next attempt
-				if attempt == 4 {
-					break
+				callIndex := calls - 1
+				if callIndex < len(tc.statusCodes) {
+					code := tc.statusCodes[callIndex]
+					if code >= 400 {
+						resp := &Response{
+							Response: &http.Response{
+								StatusCode: code,
+								Header:     http.Header{},
+							},
+						}
+						return resp, errors.New("API error")
+					}
 				}
+				return &Response{Response: &http.Response{StatusCode: 200}}, nil
 			}
 
-			// Verify results
-			if tc.shouldSucceed {
-				if err != nil {
-					t.Errorf("Expected success but got error: %v", err)
-				}
-				if result == nil {
-					t.Error("Expected result but got nil")
-				}
-			} else {
-				if err == nil {
-					t.Error("Expected error but got success")
-				}
-			}
+			_, err := c.invoke(context.Background(), "test op", fn)
 
-			if mockClient.GetCombinedStatusCalls != tc.expectedCalls {
-				t.Errorf("Expected %d API calls, got %d", tc.expectedCalls, mockClient.GetCombinedStatusCalls)
+			if tc.shouldSucceed && err != nil {
+				t.Errorf("expected success but got error: %v", err)
+			}
+			if !tc.shouldSucceed && err == nil {
+				t.Error("expected error but got success")
+			}
+			if calls != tc.expectedCalls {
+				t.Errorf("expected %d calls, got %d", tc.expectedCalls, calls)
 			}
 		})
 	}
 }
 
-func TestListCheckRunsForRefRetry(t *testing.T) {
+func TestMockClientListAllCheckRunsForRefPagination(t *testing.T) {
+	page1 := []*CheckRun{{Name: github.String("a")}, {Name: github.String("b")}}
+	page2 := []*CheckRun{{Name: github.String("c")}}
+
+	mockClient := &MockClient{Pages: [][]*CheckRun{page1, page2}}
+
+	all, err := mockClient.ListAllCheckRunsForRef(context.Background(), "owner", "repo", "ref", nil)
+	if err != nil {
+		t.Fatalf("expected success, got error: %v", err)
+	}
+	if len(all) != 3 {
+		t.Fatalf("expected 3 check runs across pages, got %d", len(all))
+	}
+	if mockClient.ListCheckRunsForRefCalls != 2 {
+		t.Errorf("expected 2 page fetches, got %d", mockClient.ListCheckRunsForRefCalls)
+	}
+}
+
+func TestMockClientListAllCheckRunsForRefMidPaginationFailure(t *testing.T) {
+	page1 := []*CheckRun{{Name: github.String("a")}}
+	page2 := []*CheckRun{{Name: github.String("b")}}
+
+	mockClient := &MockClient{
+		Pages:       [][]*CheckRun{page1, page2},
+		StatusCodes: []int{200, 500},
+	}
+
+	if _, err := mockClient.ListAllCheckRunsForRef(context.Background(), "owner", "repo", "ref", nil); err == nil {
+		t.Fatal("expected a failure fetching page 2, got success")
+	}
+}
+
+func TestInvokeRateLimitRetry(t *testing.T) {
+	c := newClient(nil, WithRetryPolicy(zeroDelayPolicy()), WithMaxRateLimitRetries(2), WithMaxRateLimitWait(time.Second))
+
+	calls := 0
+	fn := func() (*Response, error) {
+		calls++
+		if calls == 1 {
+			resp := &Response{
+				Response: &http.Response{
+					StatusCode: http.StatusTooManyRequests,
+					Header:     http.Header{"Retry-After": []string{"0"}},
+				},
+			}
+			return resp, errors.New("rate limited")
+		}
+		return &Response{Response: &http.Response{StatusCode: 200}}, nil
+	}
+
+	_, err := c.invoke(context.Background(), "test op", fn)
+	if err != nil {
+		t.Fatalf("expected success after rate-limit retry, got error: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("expected 2 calls, got %d", calls)
+	}
+}
+
+func TestRateLimitWait(t *testing.T) {
 	tests := map[string]struct {
-		statusCodes   []int
-		expectedCalls int
-		shouldSucceed bool
-		shouldTimeout bool
+		err         error
+		resp        *Response
+		expectOK    bool
+		expectRough time.Duration
 	}{
-		"success on first try": {
-			statusCodes:   []int{200},
-			expectedCalls: 1,
-			shouldSucceed: true,
-		},
-		"retry once then succeed": {
-			statusCodes:   []int{500, 200},
-			expectedCalls: 2,
-			shouldSucceed: true,
+		"plain 403 is not rate-limited": {
+			resp: &Response{
+				Response: &http.Response{
+					StatusCode: http.StatusForbidden,
+					Header:     http.Header{},
+				},
+			},
+			expectOK: false,
 		},
-		"retry twice then succeed": {
-			statusCodes:   []int{500, 500, 200},
-			expectedCalls: 3,
-			shouldSucceed: true,
+		"plain 404 is not rate-limited": {
+			resp: &Response{
+				Response: &http.Response{
+					StatusCode: http.StatusNotFound,
+					Header:     http.Header{},
+				},
+			},
+			expectOK: false,
 		},
-		"fail after max retries": {
-			statusCodes:   []int{500, 500, 500, 500, 500, 500},
-			expectedCalls: 5, // maxRetries
-			shouldSucceed: false,
+		"429 with Retry-After seconds": {
+			resp: &Response{
+				Response: &http.Response{
+					StatusCode: http.StatusTooManyRequests,
+					Header:     http.Header{"Retry-After": []string{"30"}},
+				},
+			},
+			expectOK:    true,
+			expectRough: 30 * time.Second,
 		},
-		"don't retry on 4xx errors": {
-			statusCodes:   []int{404},
-			expectedCalls: 1,
-			shouldSucceed: false,
+		"403 with exhausted rate limit headers": {
+			resp: &Response{
+				Response: &http.Response{
+					StatusCode: http.StatusForbidden,
+					Header: http.Header{
+						"X-Ratelimit-Remaining": []string{"0"},
+						"X-Ratelimit-Reset":     []string{"9999999999"},
+					},
+				},
+			},
+			expectOK: true,
 		},
-		"don't retry on rate limits": {
-			statusCodes:   []int{403},
-			expectedCalls: 1,
-			shouldSucceed: false,
+		"go-github RateLimitError": {
+			err: &github.RateLimitError{
+				Rate: github.Rate{Reset: github.Timestamp{Time: time.Now().Add(time.Minute)}},
+			},
+			expectOK: true,
 		},
-		"timeout during retry": {
-			shouldTimeout: true,
-			expectedCalls: 1,
-			shouldSucceed: false,
+		"go-github AbuseRateLimitError": {
+			err: func() error {
+				d := 5 * time.Second
+				return &github.AbuseRateLimitError{RetryAfter: &d}
+			}(),
+			expectOK:    true,
+			expectRough: 5 * time.Second,
 		},
 	}
 
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			mockClient := &MockClient{
-				StatusCodes:   tc.statusCodes,
-				ShouldTimeout: tc.shouldTimeout,
+			wait, ok := rateLimitWait(tc.err, tc.resp)
+			if ok != tc.expectOK {
+				t.Fatalf("expected ok=%v, got %v (wait=%v)", tc.expectOK, ok, wait)
 			}
-
-			ctx := context.Background()
-			var result *ListCheckRunsResults
-			var resp *Response
-			var err error
-			
-			// Use modified version of the retry code for testing
-			for attempt := 0; attempt < 5; attempt++ {
-				result, resp, err = mockClient.ListCheckRunsForRef(ctx, "owner", "repo", "ref", &ListCheckRunsOptions{})
-				if err == nil {
-					break
-				}
-				
-				// For timeout error, don't retry
-				if errors.Is(err, context.DeadlineExceeded) {
-					break
-				}
-				
-				// Only retry on 5xx server errors
-				if resp != nil && (resp.StatusCode < 500 || resp.StatusCode > 599) {
-					break
-				}
-				
-				// Don't actually sleep in tests, just continue to next attempt
-				if attempt == 4 {
-					break
-				}
+			if tc.expectRough != 0 && (wait < tc.expectRough-time.Second || wait > tc.expectRough+time.Second) {
+				t.Errorf("expected wait near %v, got %v", tc.expectRough, wait)
 			}
+		})
+	}
+}
 
-			// Verify results
-			if tc.shouldSucceed {
-				if err != nil {
-					t.Errorf("Expected success but got error: %v", err)
-				}
-				if result == nil {
-					t.Error("Expected result but got nil")
-				}
-			} else {
-				if err == nil {
-					t.Error("Expected error but got success")
-				}
-			}
+// TestGetCombinedStatusETagCache drives a [200, 304, 304, 200] sequence of
+// responses through a real *client against an httptest server and checks
+// that the two 304s return the cached payload instead of a fresh decode.
+func TestGetCombinedStatusETagCache(t *testing.T) {
+	codes := []int{200, 304, 304, 200}
+	calls := 0
 
-			if mockClient.ListCheckRunsForRefCalls != tc.expectedCalls {
-				t.Errorf("Expected %d API calls, got %d", tc.expectedCalls, mockClient.ListCheckRunsForRefCalls)
-			}
-		})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/owner/repo/commits/ref/status", func(w http.ResponseWriter, r *http.Request) {
+		code := codes[calls]
+		calls++
+
+		w.Header().Set("ETag", `"the-etag"`)
+		if code == http.StatusNotModified {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, `{"state":"success","total_count":%d}`, calls)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	ghc := github.NewClient(nil)
+	baseURL, err := url.Parse(srv.URL + "/")
+	if err != nil {
+		t.Fatalf("failed to parse test server URL: %v", err)
+	}
+	ghc.BaseURL = baseURL
+
+	c := newClient(ghc, WithETagCache(10))
+
+	var results []*CombinedStatus
+	for i := range codes {
+		status, _, err := c.GetCombinedStatus(context.Background(), "owner", "repo", "ref", nil)
+		if err != nil {
+			t.Fatalf("call %d: unexpected error: %v", i, err)
+		}
+		results = append(results, status)
+	}
+
+	if calls != len(codes) {
+		t.Fatalf("expected %d HTTP round trips, got %d", len(codes), calls)
+	}
+	// Cache hits return a clone of the cached payload, never the literal
+	// cached pointer (so a caller mutating its result can't corrupt the
+	// cache or race another caller) - so compare by value, not identity.
+	if results[1] == results[0] {
+		t.Error("expected a cache hit to return a clone, not the cached pointer itself")
+	}
+	if results[1].GetTotalCount() != results[0].GetTotalCount() {
+		t.Errorf("expected the first 304 to carry call 0's payload, got total_count=%d want %d", results[1].GetTotalCount(), results[0].GetTotalCount())
+	}
+	if results[2].GetTotalCount() != results[0].GetTotalCount() {
+		t.Errorf("expected the second 304 to carry call 0's payload, got total_count=%d want %d", results[2].GetTotalCount(), results[0].GetTotalCount())
+	}
+	if results[3].GetTotalCount() == results[0].GetTotalCount() {
+		t.Error("expected the final 200 to return a freshly decoded payload")
+	}
+}
+
+// TestGetCombinedStatusEscapesRef checks that a ref containing a reserved
+// character reaches the server escaped and intact. Without escaping, "#" in
+// particular would be parsed as the start of a URL fragment by
+// ghc.NewRequest's BaseURL.Parse, silently truncating the path and hitting
+// the wrong ref.
+func TestGetCombinedStatusEscapesRef(t *testing.T) {
+	const ref = "weird#ref"
+	var gotPath string
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/owner/repo/commits/weird#ref/status", func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		fmt.Fprint(w, `{"state":"success","total_count":0}`)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	ghc := github.NewClient(nil)
+	baseURL, err := url.Parse(srv.URL + "/")
+	if err != nil {
+		t.Fatalf("failed to parse test server URL: %v", err)
+	}
+	ghc.BaseURL = baseURL
+
+	c := newClient(ghc)
+	if _, _, err := c.GetCombinedStatus(context.Background(), "owner", "repo", ref, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if gotPath != "/repos/owner/repo/commits/weird#ref/status" {
+		t.Errorf("expected the handler to see the escaped, untruncated ref in the path, got %q", gotPath)
+	}
+}
+
+// TestGetFullCombinedStatusETagCachePerPage reproduces the maintainer's
+// report: without a per-page cache key, a paginated ref could never get a
+// real cache hit because every page's ETag fetch/store collided on one
+// shared slot. With the fix, a second polling cycle over the same pages
+// gets a 304 for every page.
+func TestGetFullCombinedStatusETagCachePerPage(t *testing.T) {
+	etags := map[string]string{"1": `"etag-page-1"`, "2": `"etag-page-2"`}
+	var page1Codes, page2Codes []int
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/owner/repo/commits/ref/status", func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page")
+		if page == "" {
+			page = "1"
+		}
+		etag := etags[page]
+
+		w.Header().Set("ETag", etag)
+		if page == "1" {
+			w.Header().Set("Link", fmt.Sprintf(`<http://%s%s?page=2>; rel="next"`, r.Host, r.URL.Path))
+		}
+
+		code := http.StatusOK
+		if r.Header.Get("If-None-Match") == etag {
+			code = http.StatusNotModified
+		}
+
+		if page == "1" {
+			page1Codes = append(page1Codes, code)
+		} else {
+			page2Codes = append(page2Codes, code)
+		}
+
+		if code == http.StatusNotModified {
+			w.WriteHeader(code)
+			return
+		}
+		w.WriteHeader(code)
+		// Real GitHub combined-status pages all report the same full
+		// total_count, not a per-page count - both pages return 2 here so
+		// the test actually exercises that, instead of happening to sum to
+		// the right answer.
+		if page == "2" {
+			fmt.Fprint(w, `{"state":"success","total_count":2,"statuses":[{"context":"b"}]}`)
+		} else {
+			fmt.Fprint(w, `{"state":"success","total_count":2,"statuses":[{"context":"a"}]}`)
+		}
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	ghc := github.NewClient(nil)
+	baseURL, err := url.Parse(srv.URL + "/")
+	if err != nil {
+		t.Fatalf("failed to parse test server URL: %v", err)
+	}
+	ghc.BaseURL = baseURL
+
+	c := newClient(ghc, WithETagCache(10))
+
+	for cycle := 0; cycle < 2; cycle++ {
+		full, err := c.GetFullCombinedStatus(context.Background(), "owner", "repo", "ref")
+		if err != nil {
+			t.Fatalf("cycle %d: unexpected error: %v", cycle, err)
+		}
+		if len(full.Statuses) != 2 {
+			t.Fatalf("cycle %d: expected 2 aggregated statuses, got %d", cycle, len(full.Statuses))
+		}
+		// Each page reports total_count=2 for the full set, so summing
+		// across pages (the old bug) would produce 4 here instead of 2.
+		if full.GetTotalCount() != 2 {
+			t.Errorf("cycle %d: expected aggregated total_count 2, got %d", cycle, full.GetTotalCount())
+		}
+	}
+
+	wantCodes := []int{http.StatusOK, http.StatusNotModified}
+	for _, got := range [][]int{page1Codes, page2Codes} {
+		if len(got) != 2 || got[0] != wantCodes[0] || got[1] != wantCodes[1] {
+			t.Errorf("expected response codes %v across two polling cycles, got %v", wantCodes, got)
+		}
 	}
 }
\ No newline at end of file